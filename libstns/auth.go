@@ -0,0 +1,48 @@
+package libstns
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Authenticator lets callers plug in how requests are authenticated
+// against the STNS API, beyond the built-in token/basic-auth modes.
+// Apply decorates an outgoing request; Refresh is given the 401
+// response to a request Apply decorated, so a challenge/response
+// scheme like SPNEGO can negotiate new credentials. The request is
+// re-issued once, and only if Refresh returns nil.
+type Authenticator interface {
+	Apply(req *http.Request) error
+	Refresh(resp *http.Response) error
+}
+
+// TokenAuthenticator sends a static "token" bearer credential, the
+// same scheme Options.AuthToken has always used.
+type TokenAuthenticator struct {
+	Token string
+}
+
+func (a *TokenAuthenticator) Apply(req *http.Request) error {
+	req.Header.Set("Authorization", fmt.Sprintf("token %s", a.Token))
+	return nil
+}
+
+func (a *TokenAuthenticator) Refresh(resp *http.Response) error {
+	return nil
+}
+
+// BasicAuthenticator sends a static username/password pair, the same
+// scheme Options.User/Options.Password have always used.
+type BasicAuthenticator struct {
+	User     string
+	Password string
+}
+
+func (a *BasicAuthenticator) Apply(req *http.Request) error {
+	req.SetBasicAuth(a.User, a.Password)
+	return nil
+}
+
+func (a *BasicAuthenticator) Refresh(resp *http.Response) error {
+	return nil
+}
@@ -0,0 +1,170 @@
+package libstns
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+var errNotFound = errors.New("not found")
+
+// LookupErrors collects the per-name errors from a batch lookup that
+// only partially succeeded, keyed by the name that failed.
+type LookupErrors map[string]error
+
+func (e LookupErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for name, err := range e {
+		msgs = append(msgs, name+": "+err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// GetUsersByNames looks up many users in as few round-trips as
+// possible. Names are packed into batches of at most opt.MaxBatchSize
+// and requested as a single comma-joined path segment; if the server
+// doesn't support the batch endpoint (404/501), it falls back to one
+// request per name, issued in parallel. Names with no match are
+// omitted from the returned map and reported via the LookupErrors
+// error, alongside any successfully resolved users.
+func (s *STNS) GetUsersByNames(names []string) (map[string]*User, error) {
+	users := map[string]*User{}
+	if len(names) == 0 {
+		return users, nil
+	}
+	lookupErrs := LookupErrors{}
+
+	for _, batch := range chunkStrings(names, s.opt.MaxBatchSize) {
+		resp, err := s.Request(path.Join("user", "name", strings.Join(batch, ",")), "")
+		if err != nil {
+			if resp == nil || !isBatchUnsupported(resp.StatusCode) {
+				return users, err
+			}
+
+			s.getUsersByNamesFallback(batch, users, lookupErrs)
+			continue
+		}
+
+		batchUsers := []*User{}
+		if err := json.Unmarshal(resp.Body, &batchUsers); err != nil {
+			return users, err
+		}
+		for _, u := range batchUsers {
+			users[u.Name] = u
+		}
+		for _, name := range batch {
+			if _, ok := users[name]; !ok {
+				lookupErrs[name] = errNotFound
+			}
+		}
+	}
+
+	if len(lookupErrs) > 0 {
+		return users, lookupErrs
+	}
+	return users, nil
+}
+
+func (s *STNS) getUsersByNamesFallback(names []string, users map[string]*User, lookupErrs LookupErrors) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			user, err := s.GetUserByName(name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				lookupErrs[name] = err
+				return
+			}
+			users[name] = user
+		}(name)
+	}
+	wg.Wait()
+}
+
+// GetGroupsByNames is the group analogue of GetUsersByNames.
+func (s *STNS) GetGroupsByNames(names []string) (map[string]*Group, error) {
+	groups := map[string]*Group{}
+	if len(names) == 0 {
+		return groups, nil
+	}
+	lookupErrs := LookupErrors{}
+
+	for _, batch := range chunkStrings(names, s.opt.MaxBatchSize) {
+		resp, err := s.Request(path.Join("group", "name", strings.Join(batch, ",")), "")
+		if err != nil {
+			if resp == nil || !isBatchUnsupported(resp.StatusCode) {
+				return groups, err
+			}
+
+			s.getGroupsByNamesFallback(batch, groups, lookupErrs)
+			continue
+		}
+
+		batchGroups := []*Group{}
+		if err := json.Unmarshal(resp.Body, &batchGroups); err != nil {
+			return groups, err
+		}
+		for _, g := range batchGroups {
+			groups[g.Name] = g
+		}
+		for _, name := range batch {
+			if _, ok := groups[name]; !ok {
+				lookupErrs[name] = errNotFound
+			}
+		}
+	}
+
+	if len(lookupErrs) > 0 {
+		return groups, lookupErrs
+	}
+	return groups, nil
+}
+
+func (s *STNS) getGroupsByNamesFallback(names []string, groups map[string]*Group, lookupErrs LookupErrors) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			group, err := s.GetGroupByName(name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				lookupErrs[name] = err
+				return
+			}
+			groups[name] = group
+		}(name)
+	}
+	wg.Wait()
+}
+
+func isBatchUnsupported(statusCode int) bool {
+	return statusCode == http.StatusNotFound || statusCode == http.StatusNotImplemented
+}
+
+func chunkStrings(items []string, size int) [][]string {
+	if size <= 0 {
+		size = DefaultMaxBatchSize
+	}
+
+	chunks := make([][]string, 0, (len(items)+size-1)/size)
+	for size < len(items) {
+		items, chunks = items[size:], append(chunks, items[:size:size])
+	}
+	return append(chunks, items)
+}
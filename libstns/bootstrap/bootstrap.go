@@ -0,0 +1,275 @@
+// Package bootstrap enrolls and renews short-lived mTLS client
+// certificates from a step-ca-compatible issuer, so operators don't
+// have to hand-provision libstns clients with long-lived certificates
+// and keys.
+package bootstrap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config describes how to enroll a client certificate.
+type Config struct {
+	// CAURL is the step-ca base URL to enroll against, e.g.
+	// "https://ca.internal:9000".
+	CAURL string
+	// Fingerprint pins the issuer's root certificate (the sha256 hex
+	// digest of its DER encoding), as used by step-ca's bootstrap flow.
+	Fingerprint string
+	// Token authorizes the initial enrollment, a step-ca one-time
+	// token (OTT).
+	Token string
+	// RenewBefore triggers a renewal this long before the certificate's
+	// NotAfter.
+	RenewBefore time.Duration
+}
+
+// Bootstrapper holds the enrolled certificate and keeps it renewed for
+// as long as the process runs.
+type Bootstrapper struct {
+	cfg Config
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	rootPool *x509.CertPool
+}
+
+// rootsResponse is step-ca's /roots response body: the CA's current set
+// of trusted root certificates, PEM-encoded.
+type rootsResponse struct {
+	Crts []string `json:"crts"`
+}
+
+// signRequest is step-ca's /1.0/sign request body: a PEM-encoded CSR
+// authorized by a one-time token.
+type signRequest struct {
+	CSR string `json:"csr"`
+	OTT string `json:"ott"`
+}
+
+// signResponse is step-ca's /1.0/sign response body.
+type signResponse struct {
+	Crt string `json:"crt"`
+	CA  string `json:"ca"`
+}
+
+// New enrolls an initial certificate and returns a Bootstrapper ready
+// to be installed on a tls.Config via GetClientCertificate.
+func New(cfg Config) (*Bootstrapper, error) {
+	b := &Bootstrapper{cfg: cfg}
+	if err := b.enroll(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetClientCertificate satisfies tls.Config.GetClientCertificate,
+// always returning the most recently enrolled certificate.
+func (b *Bootstrapper) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.cert, nil
+}
+
+// Start renews the certificate in the background, shortly before it
+// expires, until the process exits.
+func (b *Bootstrapper) Start() {
+	go func() {
+		for {
+			time.Sleep(b.timeUntilRenewal())
+
+			if err := b.enroll(); err != nil {
+				logrus.Errorf("bootstrap certificate renewal error:%s", err.Error())
+				time.Sleep(time.Minute)
+				continue
+			}
+			logrus.Infof("renewed bootstrap client certificate")
+		}
+	}()
+}
+
+func (b *Bootstrapper) timeUntilRenewal() time.Duration {
+	b.mu.RLock()
+	cert := b.cert
+	b.mu.RUnlock()
+
+	if cert == nil || cert.Leaf == nil {
+		return b.cfg.RenewBefore
+	}
+
+	d := time.Until(cert.Leaf.NotAfter) - b.cfg.RenewBefore
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// enroll generates a fresh client key and CSR, exchanges it for a
+// signed certificate via step-ca's /1.0/sign endpoint, and stores the
+// result.
+func (b *Bootstrapper) enroll() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generate client key error:%s", err.Error())
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "libstns-go"},
+	}, key)
+	if err != nil {
+		return fmt.Errorf("create CSR error:%s", err.Error())
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	reqBody, err := json.Marshal(signRequest{CSR: string(csrPEM), OTT: b.cfg.Token})
+	if err != nil {
+		return fmt.Errorf("marshal sign request error:%s", err.Error())
+	}
+
+	client, err := b.httpClient()
+	if err != nil {
+		return fmt.Errorf("resolve CA root error:%s", err.Error())
+	}
+
+	resp, err := client.Post(strings.TrimRight(b.cfg.CAURL, "/")+"/1.0/sign", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("step-ca sign request error:%s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read step-ca sign response error:%s", err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("step-ca sign request failed: status=%d body=%s", resp.StatusCode, string(respBody))
+	}
+
+	var signResp signResponse
+	if err := json.Unmarshal(respBody, &signResp); err != nil {
+		return fmt.Errorf("decode step-ca sign response error:%s", err.Error())
+	}
+
+	certBlock, _ := pem.Decode([]byte(signResp.Crt))
+	if certBlock == nil {
+		return fmt.Errorf("no certificate in step-ca sign response")
+	}
+
+	leaf, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("parse issued certificate error:%s", err.Error())
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{certBlock.Bytes},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+
+	b.mu.Lock()
+	b.cert = cert
+	b.mu.Unlock()
+
+	return nil
+}
+
+// httpClient returns an http.Client trusting only the issuer's root
+// certificate, resolved by fingerprint via resolveRootPool, matching
+// step-ca's bootstrap flow. With no Fingerprint configured (e.g. a
+// plain-HTTP CA in tests), normal system trust applies.
+func (b *Bootstrapper) httpClient() (*http.Client, error) {
+	if b.cfg.Fingerprint == "" {
+		return http.DefaultClient, nil
+	}
+
+	pool, err := b.resolveRootPool()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}, nil
+}
+
+// resolveRootPool fetches step-ca's trusted roots from its /roots
+// endpoint, finds the one matching cfg.Fingerprint (the sha256 hex
+// digest of its DER encoding, as used by `step ca bootstrap
+// --fingerprint`), and returns a pool containing just that root. The
+// /roots fetch itself can't be verified against a pool we don't have
+// yet, so it's done with InsecureSkipVerify; everything enrolled
+// afterwards is verified against the pinned root instead.
+func (b *Bootstrapper) resolveRootPool() (*x509.CertPool, error) {
+	b.mu.RLock()
+	pool := b.rootPool
+	b.mu.RUnlock()
+	if pool != nil {
+		return pool, nil
+	}
+
+	insecureClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	resp, err := insecureClient.Get(strings.TrimRight(b.cfg.CAURL, "/") + "/roots")
+	if err != nil {
+		return nil, fmt.Errorf("fetch CA roots error:%s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read CA roots response error:%s", err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch CA roots failed: status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	var roots rootsResponse
+	if err := json.Unmarshal(body, &roots); err != nil {
+		return nil, fmt.Errorf("decode CA roots response error:%s", err.Error())
+	}
+
+	for _, crtPEM := range roots.Crts {
+		block, _ := pem.Decode([]byte(crtPEM))
+		if block == nil {
+			continue
+		}
+		sum := sha256.Sum256(block.Bytes)
+		if hex.EncodeToString(sum[:]) != b.cfg.Fingerprint {
+			continue
+		}
+
+		pool = x509.NewCertPool()
+		pool.AppendCertsFromPEM([]byte(crtPEM))
+
+		b.mu.Lock()
+		b.rootPool = pool
+		b.mu.Unlock()
+		return pool, nil
+	}
+
+	return nil, fmt.Errorf("no CA root matched fingerprint %s", b.cfg.Fingerprint)
+}
@@ -0,0 +1,201 @@
+package bootstrap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newFakeCA starts an httptest server that plays step-ca's /1.0/sign
+// endpoint: it signs whatever CSR it's handed with a freshly generated
+// test CA key.
+func newFakeCA(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake step-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA cert: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("parse CA cert: %v", err)
+	}
+
+	return httptest.NewServer(signHandler(caCert, caDER, caKey))
+}
+
+func TestNewEnrollsClientCertificate(t *testing.T) {
+	ca := newFakeCA(t)
+	defer ca.Close()
+
+	b, err := New(Config{CAURL: ca.URL, Token: "test-ott", RenewBefore: time.Minute})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	cert, err := b.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetClientCertificate() error = %v", err)
+	}
+	if cert == nil || cert.Leaf == nil {
+		t.Fatal("GetClientCertificate() returned no leaf certificate")
+	}
+	if cert.PrivateKey == nil {
+		t.Fatal("GetClientCertificate() returned no private key")
+	}
+	if cert.Leaf.Subject.CommonName != "libstns-go" {
+		t.Fatalf("leaf CommonName = %q, want %q", cert.Leaf.Subject.CommonName, "libstns-go")
+	}
+}
+
+// signHandler serves step-ca's /1.0/sign endpoint as newFakeCA does,
+// and additionally serves /roots the way a real step-ca does, so tests
+// can exercise Fingerprint-pinned enrollment.
+func signHandler(caCert *x509.Certificate, caDER []byte, caKey *ecdsa.PrivateKey) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/roots" {
+			json.NewEncoder(w).Encode(rootsResponse{
+				Crts: []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))},
+			})
+			return
+		}
+
+		var req signRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		csrBlock, _ := pem.Decode([]byte(req.CSR))
+		if csrBlock == nil {
+			http.Error(w, "no CSR in request", http.StatusBadRequest)
+			return
+		}
+		csr, err := x509.ParseCertificateRequest(csrBlock.Bytes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		leafTemplate := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      csr.Subject,
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(30 * time.Minute),
+		}
+		leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, csr.PublicKey, caKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp := signResponse{
+			Crt: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})),
+			CA:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// newFakeTLSCA starts an httptest TLS server playing step-ca's /1.0/sign
+// and /roots endpoints, pinned via the TLS server's own (self-signed)
+// certificate, and returns the server along with that root's fingerprint.
+func newFakeTLSCA(t *testing.T) (ca *httptest.Server, fingerprint string) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake step-ca"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA cert: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("parse CA cert: %v", err)
+	}
+
+	ca = httptest.NewUnstartedServer(signHandler(caCert, caDER, caKey))
+	ca.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{caDER},
+		PrivateKey:  caKey,
+		Leaf:        caCert,
+	}}}
+	ca.StartTLS()
+
+	sum := sha256.Sum256(caDER)
+	return ca, hex.EncodeToString(sum[:])
+}
+
+func TestNewEnrollsClientCertificateWithPinnedFingerprint(t *testing.T) {
+	ca, fingerprint := newFakeTLSCA(t)
+	defer ca.Close()
+
+	b, err := New(Config{CAURL: ca.URL, Token: "test-ott", Fingerprint: fingerprint, RenewBefore: time.Minute})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	cert, err := b.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetClientCertificate() error = %v", err)
+	}
+	if cert == nil || cert.Leaf == nil {
+		t.Fatal("GetClientCertificate() returned no leaf certificate")
+	}
+}
+
+func TestNewFailsOnWrongFingerprint(t *testing.T) {
+	ca, _ := newFakeTLSCA(t)
+	defer ca.Close()
+
+	if _, err := New(Config{CAURL: ca.URL, Token: "test-ott", Fingerprint: "0000000000000000000000000000000000000000000000000000000000000000"}); err == nil {
+		t.Fatal("New() error = nil, want error for mismatched CA fingerprint")
+	}
+}
+
+func TestEnrollFailsOnBadToken(t *testing.T) {
+	ca := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid ott", http.StatusUnauthorized)
+	}))
+	defer ca.Close()
+
+	if _, err := New(Config{CAURL: ca.URL, Token: "bad-ott"}); err == nil {
+		t.Fatal("New() error = nil, want error for rejected token")
+	}
+}
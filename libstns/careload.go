@@ -0,0 +1,135 @@
+package libstns
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// caReloader periodically re-reads the configured CA bundle (and client
+// certificate, if any) from disk and atomically swaps them in, so an
+// STNS server's issuing CA can be rotated without restarting every
+// client process.
+type caReloader struct {
+	opt *Options
+
+	mu   sync.RWMutex
+	pool *x509.CertPool
+	cert *tls.Certificate
+}
+
+func newCAReloader(opt *Options) (*caReloader, error) {
+	r := &caReloader{opt: opt}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// apply installs VerifyConnection and GetClientCertificate on tc so
+// every handshake checks the latest CA pool and presents the latest
+// client certificate, rather than the ones captured at client
+// construction time. GetConfigForClient is a server-side-only hook
+// (crypto/tls invokes it after receiving a ClientHelloInfo, which only
+// happens to a tls.Server) and does nothing here since this is a
+// dialing client, so verification is done by hand instead, the same
+// way etcd's transport package reloads CAs for outbound connections.
+// VerifyConnection (rather than VerifyPeerCertificate) is used because
+// it's handed the negotiated ConnectionState, including ServerName, so
+// hostname/SAN matching isn't lost along with the rest of the default
+// verification that InsecureSkipVerify disables.
+func (r *caReloader) apply(opt *Options, tc *tls.Config) {
+	tc.InsecureSkipVerify = true
+	tc.VerifyConnection = r.verifyConnection
+
+	// Only take over GetClientCertificate when this reloader is the
+	// one actually managing the client cert (opt.TLS.Cert/Key set).
+	// Otherwise leave whatever was already installed alone, e.g. a
+	// NewSTNSWithBootstrap-enrolled ClientCertificateFunc.
+	if opt.TLS.Cert != "" && opt.TLS.Key != "" {
+		tc.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			if r.cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return r.cert, nil
+		}
+	}
+}
+
+// verifyConnection manually verifies the server's chain, including its
+// hostname, against the currently loaded CA pool, since
+// InsecureSkipVerify disables crypto/tls's own verification to make
+// room for this.
+func (r *caReloader) verifyConnection(cs tls.ConnectionState) error {
+	r.mu.RLock()
+	pool := r.pool
+	r.mu.RUnlock()
+
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("no certificate presented by server")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         pool,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// start reloads the CA bundle and client certificate every interval
+// until the process exits, logging each rotation.
+func (r *caReloader) start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := r.reload(); err != nil {
+				logrus.Errorf("reload CA bundle error:%s", err.Error())
+				continue
+			}
+			logrus.Infof("reloaded CA bundle from %s", r.opt.TLS.CA)
+		}
+	}()
+}
+
+func (r *caReloader) reload() error {
+	pool := x509.NewCertPool()
+	caPEM, err := ioutil.ReadFile(r.opt.TLS.CA)
+	if err != nil {
+		return err
+	}
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no CA certificates found in %s", r.opt.TLS.CA)
+	}
+
+	var cert *tls.Certificate
+	if r.opt.TLS.Cert != "" && r.opt.TLS.Key != "" {
+		c, err := loadX509KeyPair(r.opt.TLS.Cert, r.opt.TLS.Key, &r.opt.TLS)
+		if err != nil {
+			return err
+		}
+		cert = &c
+	}
+
+	r.mu.Lock()
+	r.pool = pool
+	r.cert = cert
+	r.mu.Unlock()
+
+	return nil
+}
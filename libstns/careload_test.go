@@ -0,0 +1,140 @@
+package libstns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCA generates a CA key/cert pair and a leaf certificate signed
+// by it, valid for dnsName, returning the CA's PEM bundle and the leaf
+// as a tls.Certificate ready to serve with.
+func newTestCA(t *testing.T, dnsName string) (caPEM []byte, leaf tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA cert: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("parse CA cert: %v", err)
+	}
+	caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate leaf key: %v", err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create leaf cert: %v", err)
+	}
+
+	return caPEM, tls.Certificate{
+		Certificate: [][]byte{leafDER},
+		PrivateKey:  leafKey,
+	}
+}
+
+// newTestServer starts a TLS listener presenting leaf and returns its
+// address.
+func newTestServer(t *testing.T, leaf tls.Certificate) string {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{leaf}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				if tlsConn, ok := c.(*tls.Conn); ok {
+					tlsConn.Handshake()
+				}
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func dialWithServerName(t *testing.T, tc *tls.Config, addr, serverName string) error {
+	t.Helper()
+
+	clone := tc.Clone()
+	clone.ServerName = serverName
+
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	tlsConn := tls.Client(conn, clone)
+	defer tlsConn.Close()
+	return tlsConn.Handshake()
+}
+
+func TestCAReloaderVerifyConnectionChecksHostname(t *testing.T) {
+	caPEM, leaf := newTestCA(t, "example.com")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatalf("write CA file: %v", err)
+	}
+
+	addr := newTestServer(t, leaf)
+
+	opt := &Options{TLS: TLS{CA: caFile}}
+	reloader, err := newCAReloader(opt)
+	if err != nil {
+		t.Fatalf("newCAReloader: %v", err)
+	}
+
+	tc := &tls.Config{}
+	reloader.apply(opt, tc)
+
+	if err := dialWithServerName(t, tc, addr, "example.com"); err != nil {
+		t.Fatalf("handshake for the cert's own hostname should succeed, got: %v", err)
+	}
+
+	if err := dialWithServerName(t, tc, addr, "not-example.com"); err == nil {
+		t.Fatal("handshake for a mismatched hostname should fail, got nil error")
+	}
+}
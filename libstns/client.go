@@ -17,6 +17,7 @@ import (
 	"github.com/june07/go-retryablehttp"
 	"github.com/sirupsen/logrus"
 	"github.com/thoas/go-funk"
+	"golang.org/x/net/http2"
 )
 
 var version = "0.0.1"
@@ -25,10 +26,18 @@ type TLS struct {
 	CA   string
 	Cert string
 	Key  string
+	// KeyPassphrase decrypts Key when it is an encrypted PEM/PKCS#8
+	// private key. It takes precedence over KeyPassphrasePrompt.
+	KeyPassphrase string
+	// KeyPassphrasePrompt is invoked when Key is encrypted and
+	// KeyPassphrase is empty, so operators aren't forced to store the
+	// passphrase alongside the key on disk.
+	KeyPassphrasePrompt func(keyPath string) ([]byte, error)
 }
 
 var DefaultTimeout = 15
 var DefaultRetry = 3
+var DefaultMaxBatchSize = 50
 
 type client struct {
 	ApiEndpoint string
@@ -59,8 +68,22 @@ func newClient(endpoint string, opt *Options) (*client, error) {
 		opt.RequestRetry = DefaultRetry
 	}
 
+	if opt.MaxBatchSize == 0 {
+		opt.MaxBatchSize = DefaultMaxBatchSize
+	}
+
+	if opt.Authenticator == nil {
+		switch {
+		case opt.AuthToken != "":
+			opt.Authenticator = &TokenAuthenticator{Token: opt.AuthToken}
+		case opt.User != "" && opt.Password != "":
+			opt.Authenticator = &BasicAuthenticator{User: opt.User, Password: opt.Password}
+		}
+	}
+
 	retryclient := retryablehttp.NewClient()
 	retryclient.RetryMax = opt.RequestRetry
+	retryclient.CheckRetry = http2AwareRetryPolicy
 
 	httpClient := retryclient.StandardClient()
 
@@ -78,6 +101,44 @@ func newClient(endpoint string, opt *Options) (*client, error) {
 		}
 
 		tr.TLSClientConfig = tc
+
+		if opt.ClientCertificateFunc != nil {
+			if tc == nil {
+				tc = &tls.Config{InsecureSkipVerify: opt.SkipSSLVerify}
+				tr.TLSClientConfig = tc
+			}
+			tc.GetClientCertificate = opt.ClientCertificateFunc
+		}
+
+		if opt.CAReloadInterval > 0 && opt.TLS.CA != "" {
+			if tc == nil {
+				tc = &tls.Config{InsecureSkipVerify: opt.SkipSSLVerify}
+				tr.TLSClientConfig = tc
+			}
+
+			reloader, err := newCAReloader(opt)
+			if err != nil {
+				logrus.Errorf("make CA reloader error:%s", err.Error())
+				return nil, err
+			}
+			reloader.apply(opt, tc)
+			reloader.start(opt.CAReloadInterval)
+		}
+
+		if opt.EnableHTTP2 {
+			if tc == nil {
+				tc = &tls.Config{InsecureSkipVerify: opt.SkipSSLVerify}
+				tr.TLSClientConfig = tc
+			}
+			// HTTP/2 multiplexes requests over a single connection, so
+			// keep-alives must stay on regardless of opt.HttpKeepalive.
+			tr.DisableKeepAlives = false
+			tc.NextProtos = append([]string{"h2"}, tc.NextProtos...)
+			if err := http2.ConfigureTransport(tr); err != nil {
+				logrus.Errorf("configure http2 transport error:%s", err.Error())
+				return nil, err
+			}
+		}
 	}
 
 	if strings.Index(endpoint, "unix") == 0 {
@@ -92,6 +153,10 @@ func newClient(endpoint string, opt *Options) (*client, error) {
 		endpoint = "http://unix"
 	}
 
+	if opt.ProxyProtocol != nil {
+		tr.DialContext = proxyProtocolDialContext(opt.ProxyProtocol, tr.DialContext, time.Duration(opt.RequestTimeout)*time.Second)
+	}
+
 	tr.Proxy = http.ProxyFromEnvironment
 	if opt.HttpProxy != "" {
 		proxyUrl, err := url.Parse(opt.HttpProxy)
@@ -139,13 +204,39 @@ func (h *client) Request(path, query string) (*Response, error) {
 	}
 
 	h.setHeaders(req)
-	h.setBasicAuth(req)
+	if err := h.setAuth(req); err != nil {
+		logrus.Errorf("apply authenticator error:%s", err.Error())
+		return nil, err
+	}
 
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
 		logrus.Errorf("http request error:%s", err.Error())
 		return nil, err
 	}
+
+	// A 401 may mean the authenticator has a challenge/response
+	// scheme (e.g. SPNEGO) that needs one round-trip to negotiate.
+	// The request has no body here, so re-issuing it is safe; a
+	// future body-carrying request would need req.GetBody to avoid
+	// resending an already-drained reader.
+	if resp.StatusCode == http.StatusUnauthorized && h.opt.Authenticator != nil {
+		if refreshErr := h.opt.Authenticator.Refresh(resp); refreshErr == nil {
+			resp.Body.Close()
+
+			retryReq := req.Clone(req.Context())
+			if err := h.setAuth(retryReq); err != nil {
+				logrus.Errorf("apply authenticator error:%s", err.Error())
+				return nil, err
+			}
+
+			resp, err = h.httpClient.Do(retryReq)
+			if err != nil {
+				logrus.Errorf("http request error:%s", err.Error())
+				return nil, err
+			}
+		}
+	}
 	defer resp.Body.Close()
 
 	headers := map[string]string{}
@@ -192,16 +283,16 @@ func (h *client) setHeaders(req *http.Request) {
 	}
 
 	req.Header.Set("User-Agent", h.opt.UserAgent)
-
-	if h.opt.AuthToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("token %s", h.opt.AuthToken))
-	}
 }
 
-func (h *client) setBasicAuth(req *http.Request) {
-	if h.opt.User != "" && h.opt.Password != "" {
-		req.SetBasicAuth(h.opt.User, h.opt.Password)
+// setAuth delegates request authentication to h.opt.Authenticator,
+// which newClient defaults to a TokenAuthenticator/BasicAuthenticator
+// built from AuthToken/User+Password when none is configured.
+func (h *client) setAuth(req *http.Request) error {
+	if h.opt.Authenticator == nil {
+		return nil
 	}
+	return h.opt.Authenticator.Apply(req)
 }
 
 func tlsConfig(opt *Options) (*tls.Config, error) {
@@ -219,7 +310,7 @@ func tlsConfig(opt *Options) (*tls.Config, error) {
 	}
 
 	if opt.TLS.Cert != "" && opt.TLS.Key != "" {
-		x509Cert, err := tls.LoadX509KeyPair(opt.TLS.Cert, opt.TLS.Key)
+		x509Cert, err := loadX509KeyPair(opt.TLS.Cert, opt.TLS.Key, &opt.TLS)
 		if err != nil {
 			return nil, err
 		}
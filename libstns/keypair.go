@@ -0,0 +1,86 @@
+package libstns
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/youmark/pkcs8"
+)
+
+// loadX509KeyPair behaves like tls.LoadX509KeyPair, except it also
+// understands private keys encrypted with either the legacy
+// "Proc-Type: 4,ENCRYPTED" PEM header or PKCS#8's
+// EncryptedPrivateKeyInfo. Encrypted keys are decrypted with tlsOpt's
+// KeyPassphrase, falling back to KeyPassphrasePrompt when no passphrase
+// is configured.
+func loadX509KeyPair(certFile, keyFile string, tlsOpt *TLS) (tls.Certificate, error) {
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return tls.Certificate{}, fmt.Errorf("no PEM data found in key file:%s", keyFile)
+	}
+
+	switch {
+	case x509.IsEncryptedPEMBlock(block):
+		passphrase, err := resolvePassphrase(tlsOpt, keyFile)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+
+		der, err := x509.DecryptPEMBlock(block, passphrase)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("decrypt private key error:%s", err.Error())
+		}
+
+		decryptedKeyPEM := pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der})
+		return tls.X509KeyPair(certPEM, decryptedKeyPEM)
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		passphrase, err := resolvePassphrase(tlsOpt, keyFile)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("decrypt private key error:%s", err.Error())
+		}
+
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("marshal decrypted private key error:%s", err.Error())
+		}
+
+		decryptedKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+		return tls.X509KeyPair(certPEM, decryptedKeyPEM)
+	default:
+		return tls.X509KeyPair(certPEM, keyPEM)
+	}
+}
+
+// resolvePassphrase returns tlsOpt.KeyPassphrase, falling back to
+// KeyPassphrasePrompt when no passphrase is configured.
+func resolvePassphrase(tlsOpt *TLS, keyFile string) ([]byte, error) {
+	if tlsOpt.KeyPassphrase != "" {
+		return []byte(tlsOpt.KeyPassphrase), nil
+	}
+	if tlsOpt.KeyPassphrasePrompt != nil {
+		passphrase, err := tlsOpt.KeyPassphrasePrompt(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("key passphrase prompt error:%s", err.Error())
+		}
+		return passphrase, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,52 @@
+package libstns
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// Options controls how the client talks to the STNS API. Fields are
+// populated from matching STNS_* environment variables via
+// github.com/caarlos0/env, falling back to the zero value (and the
+// defaults applied in newClient) when unset.
+type Options struct {
+	UserAgent      string            `env:"STNS_USER_AGENT"`
+	RequestTimeout int               `env:"STNS_REQUEST_TIMEOUT"`
+	RequestRetry   int               `env:"STNS_REQUEST_RETRY"`
+	HttpKeepalive  bool              `env:"STNS_HTTP_KEEPALIVE"`
+	HttpProxy      string            `env:"STNS_HTTP_PROXY"`
+	HttpHeaders    map[string]string `env:"STNS_HTTP_HEADERS"`
+	AuthToken      string            `env:"STNS_AUTH_TOKEN"`
+	User           string            `env:"STNS_USER"`
+	Password       string            `env:"STNS_PASSWORD"`
+	SkipSSLVerify  bool              `env:"STNS_SKIP_SSL_VERIFY"`
+	// EnableHTTP2 negotiates HTTP/2 over the TLS transport via ALPN.
+	// It is opt-in: STNS is commonly deployed behind HTTP/2-terminating
+	// reverse proxies, but this must still be set explicitly (or via
+	// STNS_ENABLE_HTTP2) to turn it on.
+	EnableHTTP2 bool `env:"STNS_ENABLE_HTTP2"`
+	// CAReloadInterval, when non-zero, re-reads TLS.CA (and TLS.Cert/Key,
+	// if set) from disk on that interval so a rotated STNS issuing CA is
+	// picked up without restarting the process.
+	CAReloadInterval time.Duration `env:"STNS_CA_RELOAD_INTERVAL"`
+	// MaxBatchSize caps how many names GetUsersByNames/GetGroupsByNames
+	// pack into a single batch request before splitting into multiple
+	// requests. Defaults to DefaultMaxBatchSize.
+	MaxBatchSize int `env:"STNS_MAX_BATCH_SIZE"`
+	// Authenticator, when set, takes over request authentication from
+	// AuthToken/User+Password. Set it to a *SPNEGOAuthenticator (or a
+	// custom implementation) to support other auth backends.
+	Authenticator Authenticator
+	// ClientCertificateFunc, when set, is installed as the TLS config's
+	// GetClientCertificate so the client presents whatever certificate
+	// it returns on every handshake. NewSTNSWithBootstrap uses this to
+	// hand the connection a certificate it keeps renewed in the
+	// background.
+	ClientCertificateFunc func(*tls.CertificateRequestInfo) (*tls.Certificate, error)
+	// ProxyProtocol, when set, prepends a PROXY protocol v2 header to
+	// every outbound connection before the TLS handshake, so an STNS
+	// server behind the same load balancer sees the original principal
+	// address rather than this client's egress address.
+	ProxyProtocol *ProxyProtocolConfig
+	TLS           TLS
+}
@@ -0,0 +1,65 @@
+package libstns
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pires/go-proxyproto"
+)
+
+// ProxyProtocolConfig describes the PROXY protocol v2 header to
+// prepend to outbound connections, so a load balancer or STNS server
+// behind one can recover the original principal's address.
+type ProxyProtocolConfig struct {
+	SourceAddr net.Addr
+	DestAddr   net.Addr
+	TLVs       []proxyproto.TLV
+}
+
+// proxyProtocolDialContext wraps dial so every connection it returns
+// has a PROXY protocol v2 header written to it immediately after the
+// underlying TCP/Unix connection is established, before any TLS
+// handshake takes place on top of it.
+func proxyProtocolDialContext(cfg *ProxyProtocolConfig, dial func(ctx context.Context, network, addr string) (net.Conn, error), timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: timeout}).DialContext
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		header := &proxyproto.Header{
+			Version:           2,
+			Command:           proxyproto.PROXY,
+			TransportProtocol: transportProtocol(network, cfg.SourceAddr),
+			SourceAddr:        cfg.SourceAddr,
+			DestinationAddr:   cfg.DestAddr,
+		}
+		if err := header.SetTLVs(cfg.TLVs); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		if _, err := header.WriteTo(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return conn, nil
+	}
+}
+
+func transportProtocol(network string, addr net.Addr) proxyproto.AddressFamilyAndProtocol {
+	if network == "unix" {
+		return proxyproto.UnixStream
+	}
+
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok && tcpAddr.IP != nil && tcpAddr.IP.To4() == nil {
+		return proxyproto.TCPv6
+	}
+	return proxyproto.TCPv4
+}
@@ -0,0 +1,31 @@
+package libstns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/june07/go-retryablehttp"
+	"golang.org/x/net/http2"
+)
+
+// http2AwareRetryPolicy wraps retryablehttp's default policy so that an
+// HTTP/2 GOAWAY or stream reset doesn't fail the request outright. Both
+// errors only mean the underlying connection is being recycled, not that
+// the request itself is bad, so they're retried at the request level
+// the same way a transient network error would be.
+func http2AwareRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if isHTTP2ConnectionError(err) {
+		return true, nil
+	}
+	return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+}
+
+func isHTTP2ConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var goAway http2.GoAwayError
+	var streamErr http2.StreamError
+	return errors.As(err, &goAway) || errors.As(err, &streamErr)
+}
@@ -0,0 +1,29 @@
+package libstns
+
+import (
+	"net/http"
+
+	"github.com/dpotapov/go-spnego"
+)
+
+// SPNEGOAuthenticator negotiates Kerberos credentials for STNS
+// instances fronted by a SPNEGO-authenticated reverse proxy. Apply
+// sets the Negotiate Authorization header via go-spnego's GSSAPI
+// binding (gokrb5 on non-Windows, SSPI on Windows) on every request;
+// Refresh is a no-op since Apply regenerates the token fresh each
+// time, so simply retrying after a 401 is enough to re-negotiate.
+type SPNEGOAuthenticator struct {
+	provider spnego.Provider
+}
+
+func NewSPNEGOAuthenticator() *SPNEGOAuthenticator {
+	return &SPNEGOAuthenticator{provider: spnego.New()}
+}
+
+func (a *SPNEGOAuthenticator) Apply(req *http.Request) error {
+	return a.provider.SetSPNEGOHeader(req, true)
+}
+
+func (a *SPNEGOAuthenticator) Refresh(resp *http.Response) error {
+	return nil
+}
@@ -0,0 +1,109 @@
+package libstns
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/STNS/libstns-go/libstns/bootstrap"
+)
+
+// STNS is a handle to an STNS API endpoint, built by NewSTNS.
+type STNS struct {
+	*client
+}
+
+// User mirrors the JSON shape returned by the STNS user endpoints.
+type User struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	GroupID  int      `json:"group_id"`
+	Keys     []string `json:"keys"`
+	Password string   `json:"password,omitempty"`
+}
+
+// Group mirrors the JSON shape returned by the STNS group endpoints.
+type Group struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Users []string `json:"users"`
+}
+
+// BootstrapConfig configures automatic mTLS enrollment for
+// NewSTNSWithBootstrap.
+type BootstrapConfig = bootstrap.Config
+
+// NewSTNSWithBootstrap builds an STNS client that enrolls its client
+// certificate from a step-ca-compatible issuer (via its /1.0/sign
+// endpoint) instead of reading it from opt.TLS.Cert/Key, and renews it
+// in the background for as long as the returned *STNS is in use. ACME
+// issuers are not supported.
+func NewSTNSWithBootstrap(endpoint string, cfg BootstrapConfig) (*STNS, error) {
+	b, err := bootstrap.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	b.Start()
+
+	opt := &Options{
+		ClientCertificateFunc: b.GetClientCertificate,
+	}
+
+	c, err := newClient(endpoint, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &STNS{client: c}, nil
+}
+
+// NewSTNS builds an STNS client for endpoint. opt may be nil, in which
+// case the client falls back to its built-in defaults.
+func NewSTNS(endpoint string, opt *Options) (*STNS, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+
+	c, err := newClient(endpoint, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &STNS{client: c}, nil
+}
+
+// GetUserByName looks up a single user by name.
+func (s *STNS) GetUserByName(name string) (*User, error) {
+	resp, err := s.Request(path.Join("user", "name", name), "")
+	if err != nil {
+		return nil, err
+	}
+
+	users := []*User{}
+	if err := json.Unmarshal(resp.Body, &users); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found:%s", name)
+	}
+
+	return users[0], nil
+}
+
+// GetGroupByName looks up a single group by name.
+func (s *STNS) GetGroupByName(name string) (*Group, error) {
+	resp, err := s.Request(path.Join("group", "name", name), "")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []*Group{}
+	if err := json.Unmarshal(resp.Body, &groups); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("group not found:%s", name)
+	}
+
+	return groups[0], nil
+}